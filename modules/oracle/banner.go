@@ -0,0 +1,96 @@
+package oracle
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BannerInfo holds the components of an Oracle database banner string, e.g.
+// "Oracle Database 19c Enterprise Edition Release 19.0.0.0.0 - Production".
+type BannerInfo struct {
+	// Product is the product name, including the marketing version, e.g.
+	// "Oracle Database 19c" or "Oracle9i".
+	Product string `json:"product,omitempty"`
+
+	// MarketingVersion is the short version name, e.g. "19c", "11g", "9i".
+	MarketingVersion string `json:"marketing_version,omitempty"`
+
+	// Edition is the edition, e.g. "Enterprise Edition", "Express Edition"
+	// or "Free".
+	Edition string `json:"edition,omitempty"`
+
+	// Release is the dotted number following "Release", e.g. "19.0.0.0.0".
+	Release string `json:"release,omitempty"`
+
+	// Version is the most precise dotted version available: the
+	// "Version x.y.z" line that 18c and later include in the full banner
+	// (e.g. "19.3.0.0.0"), otherwise the same as Release.
+	Version string `json:"version,omitempty"`
+
+	// Bits is the word size given by 11g/12c banners (e.g. "64bit").
+	Bits string `json:"bits,omitempty"`
+
+	// Status is the release status marker: "Production", "Development" or
+	// "Beta". Banners that don't include one of these leave it empty.
+	Status string `json:"status,omitempty"`
+}
+
+var (
+	// bannerRelease matches the first banner line, capturing the text before
+	// "Release", the release number and anything after the " - ".
+	bannerRelease = regexp.MustCompile(`^(.*?)\s*\bRelease\s+(\d+(?:\.\d+)*)\s*(?:-\s*(.*))?$`)
+
+	// bannerProduct matches the product and marketing version within the
+	// text before "Release".
+	bannerProduct = regexp.MustCompile(`Oracle\s*(?:Database\s+)?(\d+[a-z]+)\b`)
+
+	// bannerVersion matches the "Version x.y.z" line of a full banner.
+	bannerVersion = regexp.MustCompile(`(?m)^\s*Version\s+(\d+(?:\.\d+)+)\s*$`)
+
+	// bannerBits matches the word size in the text after " - ".
+	bannerBits = regexp.MustCompile(`\b(\d+)[- ]?bit\b`)
+
+	// bannerStatus matches the release status in the text after " - ". Some
+	// 10g Express banners truncate "Production" to "Product", and 23c Free
+	// developer builds say "Developer-Release".
+	bannerStatus = regexp.MustCompile(`\b(Production|Product|Development|Developer-Release|Beta)\b`)
+)
+
+// ParseBanner splits a database banner into its components. It accepts the
+// formats used from 8i through 23ai, including multi-line banners (such as
+// BANNER_FULL, or banners followed by "With the Partitioning..." lines).
+// Components that cannot be found are left empty.
+func ParseBanner(s string) BannerInfo {
+	ret := BannerInfo{}
+	s = strings.TrimSpace(s)
+	firstLine := strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+	match := bannerRelease.FindStringSubmatch(firstLine)
+	if match == nil {
+		return ret
+	}
+	head, rest := match[1], match[3]
+	ret.Release = match[2]
+	ret.Version = ret.Release
+	if loc := bannerProduct.FindStringSubmatchIndex(head); loc != nil {
+		ret.Product = strings.TrimSpace(head[:loc[1]])
+		ret.MarketingVersion = head[loc[2]:loc[3]]
+		ret.Edition = strings.TrimRight(strings.TrimSpace(head[loc[1]:]), ",;: ")
+	} else {
+		ret.Product = head
+	}
+	if m := bannerBits.FindStringSubmatch(rest); m != nil {
+		ret.Bits = m[1] + "bit"
+	}
+	if m := bannerStatus.FindStringSubmatch(rest); m != nil {
+		switch ret.Status = m[1]; ret.Status {
+		case "Product":
+			ret.Status = "Production"
+		case "Developer-Release":
+			ret.Status = "Development"
+		}
+	}
+	if m := bannerVersion.FindStringSubmatch(s); m != nil {
+		ret.Version = m[1]
+	}
+	return ret
+}
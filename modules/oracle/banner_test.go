@@ -0,0 +1,61 @@
+package oracle
+
+import (
+	"testing"
+)
+
+// banners maps banner strings to their expected components.
+var banners = map[string]BannerInfo{
+	"Oracle8i Enterprise Edition Release 8.1.7.0.0 - Production": {
+		Product: "Oracle8i", MarketingVersion: "8i", Edition: "Enterprise Edition",
+		Release: "8.1.7.0.0", Version: "8.1.7.0.0", Status: "Production",
+	},
+	"Personal Oracle9i Release 9.2.0.1.0 - Production": {
+		Product: "Personal Oracle9i", MarketingVersion: "9i",
+		Release: "9.2.0.1.0", Version: "9.2.0.1.0", Status: "Production",
+	},
+	"Oracle Database 10g Express Edition Release 10.2.0.1.0 - Product": {
+		Product: "Oracle Database 10g", MarketingVersion: "10g", Edition: "Express Edition",
+		Release: "10.2.0.1.0", Version: "10.2.0.1.0", Status: "Production",
+	},
+	"Oracle Database 11g Enterprise Edition Release 11.2.0.4.0 - 64bit Production\n" +
+		"With the Partitioning, OLAP, Data Mining and Real Application Testing options": {
+		Product: "Oracle Database 11g", MarketingVersion: "11g", Edition: "Enterprise Edition",
+		Release: "11.2.0.4.0", Version: "11.2.0.4.0", Bits: "64bit", Status: "Production",
+	},
+	"Oracle Database 12c Standard Edition Release 12.2.0.1.0 - 64bit Development": {
+		Product: "Oracle Database 12c", MarketingVersion: "12c", Edition: "Standard Edition",
+		Release: "12.2.0.1.0", Version: "12.2.0.1.0", Bits: "64bit", Status: "Development",
+	},
+	"Oracle Database 18c Express Edition Release 18.0.0.0.0 - Production\nVersion 18.4.0.0.0": {
+		Product: "Oracle Database 18c", MarketingVersion: "18c", Edition: "Express Edition",
+		Release: "18.0.0.0.0", Version: "18.4.0.0.0", Status: "Production",
+	},
+	"Oracle Database 19c Enterprise Edition Release 19.0.0.0.0 - Production": {
+		Product: "Oracle Database 19c", MarketingVersion: "19c", Edition: "Enterprise Edition",
+		Release: "19.0.0.0.0", Version: "19.0.0.0.0", Status: "Production",
+	},
+	"Oracle Database 21c Express Edition Release 21.0.0.0.0 - Production\r\nVersion 21.3.0.0.0\r\n": {
+		Product: "Oracle Database 21c", MarketingVersion: "21c", Edition: "Express Edition",
+		Release: "21.0.0.0.0", Version: "21.3.0.0.0", Status: "Production",
+	},
+	"Oracle Database 23ai Free Release 23.0.0.0.0 - Develop, Learn, and Run for Free\nVersion 23.5.0.24.07": {
+		Product: "Oracle Database 23ai", MarketingVersion: "23ai", Edition: "Free",
+		Release: "23.0.0.0.0", Version: "23.5.0.24.07",
+	},
+	"Oracle Database 23c Free, Release 23.0.0.0.0 - Developer-Release": {
+		Product: "Oracle Database 23c", MarketingVersion: "23c", Edition: "Free",
+		Release: "23.0.0.0.0", Version: "23.0.0.0.0", Status: "Development",
+	},
+	"TNSLSNR for Linux: Version 19.0.0.0.0 - Production": {},
+	"": {},
+}
+
+// TestParseBanner checks the components extracted from each banner format.
+func TestParseBanner(t *testing.T) {
+	for s, expected := range banners {
+		if actual := ParseBanner(s); actual != expected {
+			t.Errorf("ParseBanner(%q): expected %#v, got %#v", s, expected, actual)
+		}
+	}
+}
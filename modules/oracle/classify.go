@@ -0,0 +1,34 @@
+package oracle
+
+import (
+	"bytes"
+)
+
+// Protocols recognized by ClassifyNonTNS.
+const (
+	// NonTNSHTTP is an HTTP response.
+	NonTNSHTTP = "http"
+
+	// NonTNSSSH is an SSH identification string.
+	NonTNSSSH = "ssh"
+
+	// NonTNSTLS is a TLS handshake record, e.g. a ServerHello.
+	NonTNSTLS = "tls"
+)
+
+// ClassifyNonTNS checks whether the first bytes read from a server are clearly
+// not a TNS packet, returning NonTNSHTTP for an HTTP response ("HTTP/"),
+// NonTNSSSH for an SSH identification string ("SSH-"), or NonTNSTLS for a TLS
+// handshake record (0x16 0x03). It returns the empty string if none of these
+// match, in which case first may be a TNS header.
+func ClassifyNonTNS(first []byte) string {
+	switch {
+	case bytes.HasPrefix(first, []byte("HTTP/")):
+		return NonTNSHTTP
+	case bytes.HasPrefix(first, []byte("SSH-")):
+		return NonTNSSSH
+	case len(first) >= 2 && first[0] == 0x16 && first[1] == 0x03:
+		return NonTNSTLS
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package oracle
+
+import (
+	"testing"
+)
+
+// firstBytes maps the first bytes of a response to their expected
+// classification.
+var firstBytes = map[string]string{
+	"HTTP/1.1 400 Bad Request\r\n":         NonTNSHTTP,
+	"HTTP/1.0 200 OK\r\n":                  NonTNSHTTP,
+	"SSH-2.0-OpenSSH_8.9p1 Ubuntu-3\r\n":   NonTNSSSH,
+	"\x16\x03\x03\x00\x7a\x02\x00\x00\x76": NonTNSTLS,
+	"\x16\x03\x01\x00\x05":                 NonTNSTLS,
+	// TNS Accept and Refuse headers.
+	"\x00\x20\x00\x00\x02\x00\x00\x00": "",
+	"\x00\x0c\x00\x00\x04\x00\x00\x00": "",
+	"\x16":                             "",
+	"HTTP":                             "",
+	"":                                 "",
+}
+
+// TestClassifyNonTNS checks that HTTP, SSH and TLS responses are recognized
+// and that TNS headers are not.
+func TestClassifyNonTNS(t *testing.T) {
+	for first, expected := range firstBytes {
+		if actual := ClassifyNonTNS([]byte(first)); actual != expected {
+			t.Errorf("ClassifyNonTNS(%q): expected %q, got %q", first, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,766 @@
+// Package oracle contains helpers for working with Oracle TNS listeners.
+// Connect descriptors are the parenthesized NV ("name-value") strings that
+// clients send in the Connect packet and that listeners return in Redirect
+// and Refuse packets, e.g.
+// (DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=db)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=orcl))).
+package oracle
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidDescriptor is returned when a connect descriptor cannot be
+	// parsed (unbalanced parentheses, a name without a value, etc).
+	ErrInvalidDescriptor = errors.New("invalid connect descriptor")
+)
+
+// Values for the CONNECT_DATA SERVER parameter.
+const (
+	// ServerDedicated requests a dedicated server process.
+	ServerDedicated = "DEDICATED"
+
+	// ServerShared requests a shared server (dispatcher) connection.
+	ServerShared = "SHARED"
+
+	// ServerPooled requests a Database Resident Connection Pooling (DRCP)
+	// server.
+	ServerPooled = "POOLED"
+)
+
+// Values for the ADDRESS PROTOCOL parameter.
+const (
+	// ProtocolTCP is plain TCP/IP.
+	ProtocolTCP = "TCP"
+
+	// ProtocolTCPS is TCP/IP with TLS.
+	ProtocolTCPS = "TCPS"
+
+	// ProtocolIPC is local inter-process communication.
+	ProtocolIPC = "IPC"
+
+	// ProtocolWS is Oracle Net over WebSocket (19c and later).
+	ProtocolWS = "WS"
+
+	// ProtocolWSS is Oracle Net over WebSocket with TLS (19c and later).
+	ProtocolWSS = "WSS"
+
+	// ProtocolHTTPS is Oracle Net tunnelled through HTTPS.
+	ProtocolHTTPS = "HTTPS"
+)
+
+// nvPair is a single (NAME=VALUE) element of an NV string. If the value is
+// itself a list of pairs (e.g. (ADDRESS=(PROTOCOL=TCP)(HOST=x))), Children
+// holds them and Value is empty.
+type nvPair struct {
+	Name     string
+	Value    string
+	Children []*nvPair
+}
+
+// child returns the first child with the given name (case-insensitive), or
+// nil if there is none.
+func (pair *nvPair) child(name string) *nvPair {
+	for _, c := range pair.Children {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// childValue returns the value of the first child with the given name, or the
+// empty string if there is none.
+func (pair *nvPair) childValue(name string) string {
+	if c := pair.child(name); c != nil {
+		return c.Value
+	}
+	return ""
+}
+
+// nvParser holds the state for parsing a single NV string.
+type nvParser struct {
+	input string
+	pos   int
+}
+
+// skipSpace advances past any whitespace.
+func (p *nvParser) skipSpace() {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\r\n", p.input[p.pos]) != -1 {
+		p.pos++
+	}
+}
+
+// peek returns the next byte without consuming it, or 0 at the end of input.
+func (p *nvParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// readUntil consumes input up to (but not including) the first of the given
+// delimiters, and returns it with surrounding whitespace trimmed.
+func (p *nvParser) readUntil(delims string) string {
+	start := p.pos
+	for p.pos < len(p.input) && strings.IndexByte(delims, p.input[p.pos]) == -1 {
+		p.pos++
+	}
+	return strings.TrimSpace(p.input[start:p.pos])
+}
+
+// readValue consumes a leaf value. Values may be quoted with single or
+// double quotes, in which case they can contain parentheses, '=' and other
+// characters that are otherwise special; the quotes are not included in the
+// returned value.
+func (p *nvParser) readValue() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		return p.readUntil("()"), nil
+	}
+	end := strings.IndexByte(p.input[p.pos+1:], quote)
+	if end == -1 {
+		return "", ErrInvalidDescriptor
+	}
+	ret := p.input[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return ret, nil
+}
+
+// parseList reads a sequence of (NAME=VALUE) pairs, stopping at the end of
+// input or at an unmatched ')'.
+func (p *nvParser) parseList() ([]*nvPair, error) {
+	var ret []*nvPair
+	for {
+		p.skipSpace()
+		if p.peek() != '(' {
+			return ret, nil
+		}
+		pair, err := p.parsePair()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, pair)
+	}
+}
+
+// parsePair reads a single (NAME=VALUE) pair, including the parentheses.
+func (p *nvParser) parsePair() (*nvPair, error) {
+	p.pos++ // '('
+	name := p.readUntil("=()")
+	if name == "" || p.peek() != '=' {
+		return nil, ErrInvalidDescriptor
+	}
+	p.pos++ // '='
+	p.skipSpace()
+	ret := &nvPair{Name: name}
+	if p.peek() == '(' {
+		children, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		ret.Children = children
+	} else {
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		ret.Value = value
+	}
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, ErrInvalidDescriptor
+	}
+	p.pos++ // ')'
+	return ret, nil
+}
+
+// parseNVString parses the top-level list of pairs in s.
+func parseNVString(s string) ([]*nvPair, error) {
+	p := &nvParser{input: s}
+	ret, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.input) || len(ret) == 0 {
+		return nil, ErrInvalidDescriptor
+	}
+	return ret, nil
+}
+
+// DescriptorAddress is a single ADDRESS entry from a connect descriptor.
+type DescriptorAddress struct {
+	// Protocol is the PROTOCOL value, e.g. "TCP".
+	Protocol string `json:"protocol,omitempty"`
+
+	// Host is the HOST value (a hostname or IP address).
+	Host string `json:"host,omitempty"`
+
+	// Port is the PORT value.
+	Port uint16 `json:"port,omitempty"`
+
+	// HTTPSProxy is the HTTPS_PROXY value, used with the web transports.
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+
+	// HTTPSProxyPort is the HTTPS_PROXY_PORT value.
+	HTTPSProxyPort uint16 `json:"https_proxy_port,omitempty"`
+}
+
+// IsWebTransport returns true if the address uses one of the web-based
+// transports (ProtocolWS, ProtocolWSS or ProtocolHTTPS) rather than a plain
+// socket.
+func (addr *DescriptorAddress) IsWebTransport() bool {
+	switch strings.ToUpper(addr.Protocol) {
+	case ProtocolWS, ProtocolWSS, ProtocolHTTPS:
+		return true
+	}
+	return false
+}
+
+// DescriptorSecurity holds the SECURITY parameters of a connect descriptor,
+// which tell the client how it is expected to authenticate the server.
+type DescriptorSecurity struct {
+	// SSLServerCertDN is the distinguished name the client expects in the
+	// server's certificate.
+	SSLServerCertDN string `json:"ssl_server_cert_dn,omitempty"`
+
+	// SSLServerDNMatch is true if the client is told to check the server's
+	// certificate DN.
+	SSLServerDNMatch bool `json:"ssl_server_dn_match,omitempty"`
+
+	// AuthenticationService is the AUTHENTICATION_SERVICE value (e.g. TCPS
+	// or KERBEROS5).
+	AuthenticationService string `json:"authentication_service,omitempty"`
+
+	// WalletLocation is the MY_WALLET_DIRECTORY / WALLET_LOCATION value.
+	WalletLocation string `json:"wallet_location,omitempty"`
+}
+
+// DescriptorCID holds the CONNECT_DATA CID parameters identifying the client.
+// These are supplied by the client and are not verified by the server in any
+// way, so anyone can put any values here; they are nonetheless what shows up
+// in listener logs and database audit trails.
+type DescriptorCID struct {
+	// Program is the client program name.
+	Program string `json:"program,omitempty"`
+
+	// Host is the client host name.
+	Host string `json:"host,omitempty"`
+
+	// User is the client operating system user.
+	User string `json:"user,omitempty"`
+}
+
+// Descriptor is the parsed form of a connect descriptor.
+type Descriptor struct {
+	// Addresses lists every ADDRESS in the descriptor, whether it appears
+	// directly under DESCRIPTION or inside an ADDRESS_LIST, in the order in
+	// which they appear.
+	Addresses []DescriptorAddress `json:"addresses,omitempty"`
+
+	// LoadBalance is true if load balancing is in effect at any level. As in
+	// Oracle Net, LOAD_BALANCE defaults to on for a DESCRIPTION_LIST of
+	// several descriptions, and to off elsewhere.
+	LoadBalance bool `json:"load_balance,omitempty"`
+
+	// Failover is true if failover is in effect at any level. As in Oracle
+	// Net, FAILOVER defaults to on for any DESCRIPTION_LIST, DESCRIPTION or
+	// ADDRESS_LIST with more than one entry, unless explicitly turned off.
+	Failover bool `json:"failover,omitempty"`
+
+	// RetryCount is the DESCRIPTION-level RETRY_COUNT value.
+	RetryCount uint `json:"retry_count,omitempty"`
+
+	// RetryDelay is the DESCRIPTION-level RETRY_DELAY value, in seconds.
+	RetryDelay uint `json:"retry_delay,omitempty"`
+
+	// ConnectTimeout is the DESCRIPTION-level CONNECT_TIMEOUT value. It is
+	// kept as a string, since newer clients accept a unit suffix (e.g.
+	// "500 ms").
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+
+	// TransportConnectTimeout is the DESCRIPTION-level
+	// TRANSPORT_CONNECT_TIMEOUT value.
+	TransportConnectTimeout string `json:"transport_connect_timeout,omitempty"`
+
+	// ServiceName is the CONNECT_DATA SERVICE_NAME value.
+	ServiceName string `json:"service_name,omitempty"`
+
+	// SID is the CONNECT_DATA SID value.
+	SID string `json:"sid,omitempty"`
+
+	// Server is the CONNECT_DATA SERVER value (e.g. ServerDedicated),
+	// upper-cased.
+	Server string `json:"server,omitempty"`
+
+	// PoolConnectionClass is the CONNECT_DATA POOL_CONNECTION_CLASS value,
+	// which groups DRCP sessions that may be shared.
+	PoolConnectionClass string `json:"pool_connection_class,omitempty"`
+
+	// PoolPurity is the CONNECT_DATA POOL_PURITY value (NEW or SELF).
+	PoolPurity string `json:"pool_purity,omitempty"`
+
+	// PoolBoundary is the CONNECT_DATA POOL_BOUNDARY value (STATEMENT or
+	// TRANSACTION), used for implicit connection pooling.
+	PoolBoundary string `json:"pool_boundary,omitempty"`
+
+	// ColocationTag is the CONNECT_DATA COLOCATION_TAG value.
+	ColocationTag string `json:"colocation_tag,omitempty"`
+
+	// Command is the CONNECT_DATA COMMAND value, which asks the listener to
+	// run a command (e.g. "ping", "version" or "services") instead of
+	// handing off the connection.
+	Command string `json:"command,omitempty"`
+
+	// CID holds the CONNECT_DATA CID client identification, if present.
+	CID *DescriptorCID `json:"cid,omitempty"`
+
+	// Security holds the SECURITY parameters, if present.
+	Security *DescriptorSecurity `json:"security,omitempty"`
+}
+
+// parseDescriptorBool interprets an Oracle Net boolean parameter value
+// (ON/YES/TRUE, case-insensitive).
+func parseDescriptorBool(value string) bool {
+	switch strings.ToUpper(value) {
+	case "ON", "YES", "TRUE":
+		return true
+	}
+	return false
+}
+
+// parsePort parses a PORT-style value; an empty value gives 0.
+func parsePort(value string) (uint16, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return 0, ErrInvalidDescriptor
+	}
+	return uint16(v), nil
+}
+
+// parseCount parses a non-negative integer value such as RETRY_COUNT; an
+// empty value gives 0.
+func parseCount(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidDescriptor
+	}
+	return uint(v), nil
+}
+
+// parseAddress reads a single ADDRESS pair.
+func parseAddress(pair *nvPair) (*DescriptorAddress, error) {
+	ret := DescriptorAddress{
+		Protocol:   pair.childValue("PROTOCOL"),
+		Host:       pair.childValue("HOST"),
+		HTTPSProxy: pair.childValue("HTTPS_PROXY"),
+	}
+	var err error
+	if ret.Port, err = parsePort(pair.childValue("PORT")); err != nil {
+		return nil, err
+	}
+	if ret.HTTPSProxyPort, err = parsePort(pair.childValue("HTTPS_PROXY_PORT")); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// parseSecurity reads a SECURITY pair.
+func parseSecurity(pair *nvPair) *DescriptorSecurity {
+	ret := DescriptorSecurity{
+		SSLServerCertDN:       pair.childValue("SSL_SERVER_CERT_DN"),
+		SSLServerDNMatch:      parseDescriptorBool(pair.childValue("SSL_SERVER_DN_MATCH")),
+		AuthenticationService: pair.childValue("AUTHENTICATION_SERVICE"),
+		WalletLocation:        pair.childValue("MY_WALLET_DIRECTORY"),
+	}
+	if ret.WalletLocation == "" {
+		ret.WalletLocation = pair.childValue("WALLET_LOCATION")
+	}
+	return &ret
+}
+
+// parseDefaultBool parses an ON / OFF value, returning def if it is empty.
+func parseDefaultBool(value string, def bool) bool {
+	if value == "" {
+		return def
+	}
+	return parseDescriptorBool(value)
+}
+
+// parseAddressList reads the ADDRESS entries and the LOAD_BALANCE / FAILOVER
+// flags from a DESCRIPTION or ADDRESS_LIST. FAILOVER defaults to on if the
+// level holds more than one address.
+func (desc *Descriptor) parseAddressList(pair *nvPair) error {
+	start := len(desc.Addresses)
+	loadBalance, failover := "", ""
+	for _, c := range pair.Children {
+		switch strings.ToUpper(c.Name) {
+		case "ADDRESS":
+			addr, err := parseAddress(c)
+			if err != nil {
+				return err
+			}
+			desc.Addresses = append(desc.Addresses, *addr)
+		case "ADDRESS_LIST":
+			if err := desc.parseAddressList(c); err != nil {
+				return err
+			}
+		case "LOAD_BALANCE":
+			loadBalance = c.Value
+		case "FAILOVER":
+			failover = c.Value
+		}
+	}
+	desc.LoadBalance = desc.LoadBalance || parseDescriptorBool(loadBalance)
+	desc.Failover = desc.Failover || parseDefaultBool(failover, len(desc.Addresses)-start > 1)
+	return nil
+}
+
+// ParseDescriptor parses a connect descriptor. The input may either be a
+// (DESCRIPTION=...), the bare contents of one, e.g.
+// (ADDRESS=...)(CONNECT_DATA=...), or a (DESCRIPTION_LIST=...) of several
+// descriptions. For a DESCRIPTION_LIST, Addresses holds the addresses of
+// every DESCRIPTION in order, LoadBalance / Failover are true if in effect at
+// any level (including Oracle Net's defaults), and the remaining fields come
+// from the first DESCRIPTION.
+func ParseDescriptor(s string) (*Descriptor, error) {
+	pairs, err := parseNVString(s)
+	if err != nil {
+		return nil, err
+	}
+	ret := Descriptor{}
+	descriptions := []*nvPair{{Children: pairs}}
+	if len(pairs) == 1 {
+		switch strings.ToUpper(pairs[0].Name) {
+		case "DESCRIPTION":
+			descriptions = pairs
+		case "DESCRIPTION_LIST":
+			list := pairs[0]
+			descriptions = nil
+			for _, c := range list.Children {
+				if strings.EqualFold(c.Name, "DESCRIPTION") {
+					descriptions = append(descriptions, c)
+				}
+			}
+			if len(descriptions) == 0 {
+				return nil, ErrInvalidDescriptor
+			}
+			several := len(descriptions) > 1
+			ret.LoadBalance = parseDefaultBool(list.childValue("LOAD_BALANCE"), several)
+			ret.Failover = parseDefaultBool(list.childValue("FAILOVER"), several)
+		}
+	}
+	root := descriptions[0]
+	if ret.RetryCount, err = parseCount(root.childValue("RETRY_COUNT")); err != nil {
+		return nil, err
+	}
+	if ret.RetryDelay, err = parseCount(root.childValue("RETRY_DELAY")); err != nil {
+		return nil, err
+	}
+	ret.ConnectTimeout = root.childValue("CONNECT_TIMEOUT")
+	ret.TransportConnectTimeout = root.childValue("TRANSPORT_CONNECT_TIMEOUT")
+	for _, description := range descriptions {
+		if err := ret.parseAddressList(description); err != nil {
+			return nil, err
+		}
+	}
+	if connectData := root.child("CONNECT_DATA"); connectData != nil {
+		ret.ServiceName = connectData.childValue("SERVICE_NAME")
+		ret.SID = connectData.childValue("SID")
+		ret.Server = strings.ToUpper(connectData.childValue("SERVER"))
+		ret.PoolConnectionClass = connectData.childValue("POOL_CONNECTION_CLASS")
+		ret.PoolPurity = connectData.childValue("POOL_PURITY")
+		ret.PoolBoundary = connectData.childValue("POOL_BOUNDARY")
+		ret.ColocationTag = connectData.childValue("COLOCATION_TAG")
+		ret.Command = connectData.childValue("COMMAND")
+		if cid := connectData.child("CID"); cid != nil {
+			ret.CID = &DescriptorCID{
+				Program: cid.childValue("PROGRAM"),
+				Host:    cid.childValue("HOST"),
+				User:    cid.childValue("USER"),
+			}
+		}
+	}
+	if security := root.child("SECURITY"); security != nil {
+		ret.Security = parseSecurity(security)
+	}
+	return &ret, nil
+}
+
+// quoteNVValue returns value, quoted if it contains characters that are
+// special in an NV string. NV strings have no escape character, so a value
+// containing both single and double quotes cannot be written; in that case
+// the (unparseable) double-quoted value is returned along with
+// ErrInvalidDescriptor.
+func quoteNVValue(value string) (string, error) {
+	if !strings.ContainsAny(value, "()=,'\"\\#") && strings.TrimSpace(value) == value {
+		return value, nil
+	}
+	if !strings.ContainsRune(value, '"') {
+		return "\"" + value + "\"", nil
+	}
+	if !strings.ContainsRune(value, '\'') {
+		return "'" + value + "'", nil
+	}
+	return "\"" + value + "\"", ErrInvalidDescriptor
+}
+
+// nvWriter builds an NV string, keeping the first error encountered.
+type nvWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+// open writes the start of a (NAME=...) list.
+func (w *nvWriter) open(name string) {
+	w.buf.WriteString("(" + name + "=")
+}
+
+// close writes the end of a list started with open.
+func (w *nvWriter) close() {
+	w.buf.WriteString(")")
+}
+
+// list writes (NAME=...) around the pairs already written to inner, unless
+// inner is empty.
+func (w *nvWriter) list(name string, inner *nvWriter) {
+	if inner.err != nil && w.err == nil {
+		w.err = inner.err
+	}
+	if inner.buf.Len() == 0 {
+		return
+	}
+	w.open(name)
+	w.buf.Write(inner.buf.Bytes())
+	w.close()
+}
+
+// leaf writes (NAME=VALUE), quoting the value if needed.
+func (w *nvWriter) leaf(name string, value string) {
+	quoted, err := quoteNVValue(value)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.buf.WriteString("(" + name + "=" + quoted + ")")
+}
+
+// value writes (NAME=VALUE), unless value is empty.
+func (w *nvWriter) value(name string, value string) {
+	if value != "" {
+		w.leaf(name, value)
+	}
+}
+
+// boolean writes (NAME=ON) if value is true.
+func (w *nvWriter) boolean(name string, value bool) {
+	if value {
+		w.leaf(name, "ON")
+	}
+}
+
+// number writes (NAME=value), unless value is zero.
+func (w *nvWriter) number(name string, value uint) {
+	if value != 0 {
+		w.leaf(name, strconv.FormatUint(uint64(value), 10))
+	}
+}
+
+// Encode returns the descriptor as a (DESCRIPTION=...) string suitable for
+// sending in a Connect packet. Empty fields are omitted.
+// The output depends only on the field values, never on the order in which a
+// parsed descriptor listed them, so the same Descriptor always encodes to the
+// same bytes (e.g. when resending a Connect). Pairs are always written in
+// this order: each ADDRESS (with PROTOCOL, HOST, PORT, HTTPS_PROXY,
+// HTTPS_PROXY_PORT); then LOAD_BALANCE, FAILOVER, RETRY_COUNT, RETRY_DELAY,
+// CONNECT_TIMEOUT and TRANSPORT_CONNECT_TIMEOUT; then CONNECT_DATA, if any of
+// its fields are set (SERVICE_NAME, SID, SERVER, POOL_CONNECTION_CLASS,
+// POOL_PURITY, POOL_BOUNDARY, COLOCATION_TAG, COMMAND, then CID with PROGRAM,
+// HOST, USER); and
+// finally SECURITY (SSL_SERVER_CERT_DN, SSL_SERVER_DN_MATCH,
+// AUTHENTICATION_SERVICE, MY_WALLET_DIRECTORY). FAILOVER=OFF is written for
+// a descriptor with several addresses and Failover unset, since failover
+// would otherwise default to on.
+// ErrInvalidDescriptor is returned if any value contains both single and
+// double quotes, since such a value cannot be represented.
+func (desc *Descriptor) Encode() (string, error) {
+	w := &nvWriter{}
+	w.open("DESCRIPTION")
+	for _, addr := range desc.Addresses {
+		w.open("ADDRESS")
+		w.value("PROTOCOL", addr.Protocol)
+		w.value("HOST", addr.Host)
+		w.number("PORT", uint(addr.Port))
+		w.value("HTTPS_PROXY", addr.HTTPSProxy)
+		w.number("HTTPS_PROXY_PORT", uint(addr.HTTPSProxyPort))
+		w.close()
+	}
+	w.boolean("LOAD_BALANCE", desc.LoadBalance)
+	if desc.Failover {
+		w.leaf("FAILOVER", "ON")
+	} else if len(desc.Addresses) > 1 {
+		w.leaf("FAILOVER", "OFF")
+	}
+	w.number("RETRY_COUNT", desc.RetryCount)
+	w.number("RETRY_DELAY", desc.RetryDelay)
+	w.value("CONNECT_TIMEOUT", desc.ConnectTimeout)
+	w.value("TRANSPORT_CONNECT_TIMEOUT", desc.TransportConnectTimeout)
+	data := &nvWriter{}
+	data.value("SERVICE_NAME", desc.ServiceName)
+	data.value("SID", desc.SID)
+	data.value("SERVER", desc.Server)
+	data.value("POOL_CONNECTION_CLASS", desc.PoolConnectionClass)
+	data.value("POOL_PURITY", desc.PoolPurity)
+	data.value("POOL_BOUNDARY", desc.PoolBoundary)
+	data.value("COLOCATION_TAG", desc.ColocationTag)
+	data.value("COMMAND", desc.Command)
+	if cid := desc.CID; cid != nil {
+		data.open("CID")
+		data.value("PROGRAM", cid.Program)
+		data.value("HOST", cid.Host)
+		data.value("USER", cid.User)
+		data.close()
+	}
+	w.list("CONNECT_DATA", data)
+	if sec := desc.Security; sec != nil {
+		w.open("SECURITY")
+		w.value("SSL_SERVER_CERT_DN", sec.SSLServerCertDN)
+		w.boolean("SSL_SERVER_DN_MATCH", sec.SSLServerDNMatch)
+		w.value("AUTHENTICATION_SERVICE", sec.AuthenticationService)
+		w.value("MY_WALLET_DIRECTORY", sec.WalletLocation)
+		w.close()
+	}
+	w.close()
+	if w.err != nil {
+		return "", w.err
+	}
+	return w.buf.String(), nil
+}
+
+// String returns the encoded descriptor (see Encode), or the empty string if
+// it cannot be encoded. Use Encode when building a descriptor to send.
+func (desc *Descriptor) String() string {
+	ret, _ := desc.Encode()
+	return ret
+}
+
+// DescriptorTemplateValues holds the values substituted into a descriptor
+// template by ExpandDescriptorTemplate.
+type DescriptorTemplateValues struct {
+	// Target replaces {target}.
+	Target string
+
+	// Port replaces {port}; it must be non-zero if the template uses {port}.
+	Port uint16
+
+	// Service replaces {service}.
+	Service string
+
+	// SID replaces {sid}.
+	SID string
+}
+
+// ExpandDescriptorTemplate replaces the {target}, {port}, {service} and {sid}
+// placeholders in template with the given values, e.g.
+// (ADDRESS=(HOST={target})(PORT={port}))(CONNECT_DATA=(SERVICE_NAME={service})).
+// Values are quoted where necessary so that they cannot add or close pairs,
+// and ErrInvalidDescriptor is returned if values is nil, Port is zero but the
+// template uses {port}, a value cannot be safely quoted, or the result is not
+// a well-formed descriptor.
+func ExpandDescriptorTemplate(template string, values *DescriptorTemplateValues) (string, error) {
+	if values == nil {
+		return "", ErrInvalidDescriptor
+	}
+	if values.Port == 0 && strings.Contains(template, "{port}") {
+		return "", ErrInvalidDescriptor
+	}
+	replacements := []string{
+		"{target}", values.Target,
+		"{port}", strconv.Itoa(int(values.Port)),
+		"{service}", values.Service,
+		"{sid}", values.SID,
+	}
+	for i := 1; i < len(replacements); i += 2 {
+		quoted, err := quoteNVValue(replacements[i])
+		if err != nil {
+			return "", err
+		}
+		replacements[i] = quoted
+	}
+	ret := strings.NewReplacer(replacements...).Replace(template)
+	if _, err := parseNVString(ret); err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
+// canonicalSortKey returns the name pair is sorted by when canonicalizing.
+// ADDRESS and ADDRESS_LIST share a key, so that addresses keep their
+// original (failover) order relative to each other.
+func (pair *nvPair) canonicalSortKey() string {
+	if pair.Name == "ADDRESS_LIST" {
+		return "ADDRESS"
+	}
+	return pair.Name
+}
+
+// canonicalize upper-cases the names of pair and its descendants and sorts
+// each list of children by name. The sort is stable, so repeated names (e.g.
+// several DESCRIPTION entries) and ADDRESS / ADDRESS_LIST entries keep their
+// relative order.
+func (pair *nvPair) canonicalize() {
+	pair.Name = strings.ToUpper(pair.Name)
+	for _, c := range pair.Children {
+		c.canonicalize()
+	}
+	sort.SliceStable(pair.Children, func(i, j int) bool {
+		return pair.Children[i].canonicalSortKey() < pair.Children[j].canonicalSortKey()
+	})
+}
+
+// writeTo writes pair to w in compact (NAME=VALUE) form.
+func (pair *nvPair) writeTo(w *nvWriter) {
+	if len(pair.Children) == 0 {
+		w.leaf(pair.Name, pair.Value)
+		return
+	}
+	w.open(pair.Name)
+	for _, c := range pair.Children {
+		c.writeTo(w)
+	}
+	w.close()
+}
+
+// CanonicalizeDescriptor parses s and re-emits it in a normalized form, so
+// that logically identical descriptors compare equal: names are upper-cased,
+// whitespace outside of values is removed, and the pairs at each level are
+// sorted by name (keeping the relative order of repeated names and of
+// ADDRESS / ADDRESS_LIST entries, which is significant for failover). Values
+// are left as-is; ErrInvalidDescriptor is returned if one contains both single
+// and double quotes and so cannot be re-emitted.
+func CanonicalizeDescriptor(s string) (string, error) {
+	pairs, err := parseNVString(s)
+	if err != nil {
+		return "", err
+	}
+	root := &nvPair{Children: pairs}
+	root.canonicalize()
+	w := &nvWriter{}
+	for _, pair := range root.Children {
+		pair.writeTo(w)
+	}
+	if w.err != nil {
+		return "", w.err
+	}
+	return w.buf.String(), nil
+}
@@ -0,0 +1,435 @@
+package oracle
+
+import (
+	"reflect"
+	"testing"
+)
+
+// descriptors maps connect descriptor strings to their expected parsed form.
+var descriptors = map[string]Descriptor{
+	"(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=db.example.com)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=orcl)))": {
+		Addresses:   []DescriptorAddress{{Protocol: "TCP", Host: "db.example.com", Port: 1521}},
+		ServiceName: "orcl",
+	},
+	"(ADDRESS=(PROTOCOL=tcp)(HOST=10.0.0.1)(PORT=1522))(CONNECT_DATA=(SID=XE)(SERVER=dedicated))": {
+		Addresses: []DescriptorAddress{{Protocol: "tcp", Host: "10.0.0.1", Port: 1522}},
+		SID:       "XE",
+		Server:    ServerDedicated,
+	},
+	`(DESCRIPTION =
+	   (ADDRESS_LIST =
+	     (LOAD_BALANCE = ON)
+	     (FAILOVER = yes)
+	     (ADDRESS = (PROTOCOL = TCP)(HOST = rac1)(PORT = 1521))
+	     (ADDRESS = (PROTOCOL = TCP)(HOST = rac2)(PORT = 1521))
+	   )
+	   (ADDRESS = (PROTOCOL = TCP)(HOST = rac3)(PORT = 1526))
+	   (CONNECT_DATA = (SERVICE_NAME = sales.example.com))
+	 )`: {
+		Addresses: []DescriptorAddress{
+			{Protocol: "TCP", Host: "rac1", Port: 1521},
+			{Protocol: "TCP", Host: "rac2", Port: 1521},
+			{Protocol: "TCP", Host: "rac3", Port: 1526},
+		},
+		LoadBalance: true,
+		Failover:    true,
+		ServiceName: "sales.example.com",
+	},
+	"(description=(load_balance=off)(address=(host=h)(port=1)))": {
+		Addresses: []DescriptorAddress{{Host: "h", Port: 1}},
+	},
+	`(DESCRIPTION=(ADDRESS=(PROTOCOL=TCPS)(HOST=db)(PORT=2484))` +
+		`(SECURITY=(SSL_SERVER_CERT_DN="CN=db,OU=Ops (EU),O=Example, Inc.,C=US")(SSL_SERVER_DN_MATCH=yes)(MY_WALLET_DIRECTORY='/opt/wallet')))`: {
+		Addresses: []DescriptorAddress{{Protocol: "TCPS", Host: "db", Port: 2484}},
+		Security: &DescriptorSecurity{
+			SSLServerCertDN:  "CN=db,OU=Ops (EU),O=Example, Inc.,C=US",
+			SSLServerDNMatch: true,
+			WalletLocation:   "/opt/wallet",
+		},
+	},
+	"(DESCRIPTION=(RETRY_COUNT=3)(RETRY_DELAY=2)(CONNECT_TIMEOUT=500 ms)(TRANSPORT_CONNECT_TIMEOUT=3)" +
+		"(ADDRESS=(PROTOCOL=TCP)(HOST=h)(PORT=1521)))": {
+		Addresses:               []DescriptorAddress{{Protocol: "TCP", Host: "h", Port: 1521}},
+		RetryCount:              3,
+		RetryDelay:              2,
+		ConnectTimeout:          "500 ms",
+		TransportConnectTimeout: "3",
+	},
+	"(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=h)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=pdb1)(SERVER=POOLED)" +
+		"(POOL_CONNECTION_CLASS=APP1)(POOL_PURITY=SELF)(POOL_BOUNDARY=TRANSACTION)(COLOCATION_TAG=shard7)))": {
+		Addresses:           []DescriptorAddress{{Protocol: "TCP", Host: "h", Port: 1521}},
+		ServiceName:         "pdb1",
+		Server:              ServerPooled,
+		PoolConnectionClass: "APP1",
+		PoolPurity:          "SELF",
+		PoolBoundary:        "TRANSACTION",
+		ColocationTag:       "shard7",
+	},
+	"(DESCRIPTION=(CONNECT_DATA=(SID=orcl)(CID=(PROGRAM=sqlplus@audit-test)(HOST=__jdbc__)(USER=\"o'brien\"))))": {
+		SID: "orcl",
+		CID: &DescriptorCID{Program: "sqlplus@audit-test", Host: "__jdbc__", User: "o'brien"},
+	},
+	"(DESCRIPTION=(ADDRESS=(PROTOCOL=WSS)(HOST=db.example.com)(PORT=443)(HTTPS_PROXY=proxy)(HTTPS_PROXY_PORT=8080))" +
+		"(ADDRESS=(PROTOCOL=https)(HOST=db2)(PORT=443)))": {
+		Addresses: []DescriptorAddress{
+			{Protocol: ProtocolWSS, Host: "db.example.com", Port: 443, HTTPSProxy: "proxy", HTTPSProxyPort: 8080},
+			{Protocol: "https", Host: "db2", Port: 443},
+		},
+		Failover: true,
+	},
+	"(DESCRIPTION=(FAILOVER=off)(ADDRESS=(HOST=a)(PORT=1521))(ADDRESS=(HOST=b)(PORT=1521)))": {
+		Addresses: []DescriptorAddress{{Host: "a", Port: 1521}, {Host: "b", Port: 1521}},
+	},
+	"(CONNECT_DATA=(COMMAND=ping))": {
+		Command: "ping",
+	},
+	"(DESCRIPTION=(CONNECT_DATA=(CID=(PROGRAM=)(HOST=scanner)(USER=))(COMMAND=version)))": {
+		Command: "version",
+		CID:     &DescriptorCID{Host: "scanner"},
+	},
+	"(DESCRIPTION=(SECURITY=(AUTHENTICATION_SERVICE=KERBEROS5)))": {
+		Security: &DescriptorSecurity{AuthenticationService: "KERBEROS5"},
+	},
+}
+
+// invalidDescriptors cannot be parsed.
+var invalidDescriptors = []string{
+	"",
+	"DESCRIPTION=(ADDRESS=(HOST=h))",
+	"(DESCRIPTION=(ADDRESS=(HOST=h))",
+	"(DESCRIPTION=(ADDRESS=(HOST=h))))",
+	"(=x)",
+	"(HOST)",
+	"(ADDRESS=(HOST=h)(PORT=65536))",
+	"(ADDRESS=(HOST=h)(PORT=abc))",
+	"(ADDRESS=(PROTOCOL=WSS)(HTTPS_PROXY_PORT=x))",
+	`(SECURITY=(SSL_SERVER_CERT_DN="CN=db))`,
+	`(SECURITY=(SSL_SERVER_CERT_DN="CN=db" x))`,
+	"(DESCRIPTION=(RETRY_COUNT=three))",
+	"(DESCRIPTION=(RETRY_DELAY=-1))",
+}
+
+// TestParseDescriptor checks that descriptors are parsed into the expected
+// fields.
+func TestParseDescriptor(t *testing.T) {
+	for s, expected := range descriptors {
+		actual, err := ParseDescriptor(s)
+		if err != nil {
+			t.Errorf("Error parsing %s: %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(*actual, expected) {
+			t.Errorf("Parse mismatch for %s: expected %#v, got %#v", s, expected, *actual)
+		}
+	}
+}
+
+// TestParseDescriptorList checks that a DESCRIPTION_LIST yields the addresses
+// of every DESCRIPTION, that unset LOAD_BALANCE / FAILOVER flags take Oracle
+// Net's defaults, and that an empty list is rejected.
+func TestParseDescriptorList(t *testing.T) {
+	s := "(DESCRIPTION_LIST=(LOAD_BALANCE=off)(FAILOVER=on)" +
+		"(DESCRIPTION=(ADDRESS_LIST=(ADDRESS=(PROTOCOL=TCP)(HOST=primary1)(PORT=1521))(ADDRESS=(PROTOCOL=TCP)(HOST=primary2)(PORT=1521)))" +
+		"(CONNECT_DATA=(SERVICE_NAME=sales))(RETRY_COUNT=3))" +
+		"(DESCRIPTION=(LOAD_BALANCE=on)(ADDRESS=(PROTOCOL=TCP)(HOST=standby)(PORT=1522))(CONNECT_DATA=(SERVICE_NAME=sales_dg))))"
+	expected := Descriptor{
+		Addresses: []DescriptorAddress{
+			{Protocol: "TCP", Host: "primary1", Port: 1521},
+			{Protocol: "TCP", Host: "primary2", Port: 1521},
+			{Protocol: "TCP", Host: "standby", Port: 1522},
+		},
+		LoadBalance: true,
+		Failover:    true,
+		RetryCount:  3,
+		ServiceName: "sales",
+	}
+	actual, err := ParseDescriptor(s)
+	if err != nil {
+		t.Fatalf("Error parsing %s: %v", s, err)
+	}
+	if !reflect.DeepEqual(*actual, expected) {
+		t.Errorf("Parse mismatch for %s: expected %#v, got %#v", s, expected, *actual)
+	}
+	// Without explicit flags, Oracle Net's defaults apply.
+	defaults := map[string]Descriptor{
+		"(DESCRIPTION=(ADDRESS=(HOST=rac1)(PORT=1521))(ADDRESS=(HOST=rac2)(PORT=1521)))": {
+			Addresses: []DescriptorAddress{{Host: "rac1", Port: 1521}, {Host: "rac2", Port: 1521}},
+			Failover:  true,
+		},
+		"(DESCRIPTION=(ADDRESS_LIST=(ADDRESS=(HOST=rac1)(PORT=1521))(ADDRESS=(HOST=rac2)(PORT=1521))))": {
+			Addresses: []DescriptorAddress{{Host: "rac1", Port: 1521}, {Host: "rac2", Port: 1521}},
+			Failover:  true,
+		},
+		"(DESCRIPTION_LIST=(DESCRIPTION=(ADDRESS=(HOST=a)(PORT=1521)))(DESCRIPTION=(ADDRESS=(HOST=b)(PORT=1521))))": {
+			Addresses:   []DescriptorAddress{{Host: "a", Port: 1521}, {Host: "b", Port: 1521}},
+			LoadBalance: true,
+			Failover:    true,
+		},
+		"(DESCRIPTION_LIST=(DESCRIPTION=(ADDRESS=(HOST=a)(PORT=1521))))": {
+			Addresses: []DescriptorAddress{{Host: "a", Port: 1521}},
+		},
+	}
+	for s, expected := range defaults {
+		actual, err := ParseDescriptor(s)
+		if err != nil {
+			t.Errorf("Error parsing %s: %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(*actual, expected) {
+			t.Errorf("Parse mismatch for %s: expected %#v, got %#v", s, expected, *actual)
+		}
+	}
+	if ret, err := ParseDescriptor("(DESCRIPTION_LIST=(LOAD_BALANCE=on))"); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor for empty DESCRIPTION_LIST, got %#v, %v", ret, err)
+	}
+}
+
+// TestParseInvalidDescriptor checks that malformed descriptors return
+// ErrInvalidDescriptor.
+func TestParseInvalidDescriptor(t *testing.T) {
+	for _, s := range invalidDescriptors {
+		if ret, err := ParseDescriptor(s); err != ErrInvalidDescriptor {
+			t.Errorf("Expected ErrInvalidDescriptor for %s, got %#v, %v", s, ret, err)
+		}
+	}
+}
+
+// TestDescriptorString checks the builder output for a fully-populated
+// descriptor.
+func TestDescriptorString(t *testing.T) {
+	desc := Descriptor{
+		Addresses: []DescriptorAddress{
+			{Protocol: "TCP", Host: "rac1", Port: 1521},
+			{Protocol: "TCP", Host: "rac2"},
+		},
+		LoadBalance: true,
+		ServiceName: "orcl",
+		Server:      ServerPooled,
+		Security:    &DescriptorSecurity{SSLServerCertDN: "CN=db,O=Example"},
+	}
+	expected := "(DESCRIPTION=" +
+		"(ADDRESS=(PROTOCOL=TCP)(HOST=rac1)(PORT=1521))" +
+		"(ADDRESS=(PROTOCOL=TCP)(HOST=rac2))" +
+		"(LOAD_BALANCE=ON)(FAILOVER=OFF)" +
+		"(CONNECT_DATA=(SERVICE_NAME=orcl)(SERVER=POOLED))" +
+		`(SECURITY=(SSL_SERVER_CERT_DN="CN=db,O=Example")))`
+	actual, err := desc.Encode()
+	if err != nil {
+		t.Fatalf("Error encoding descriptor: %v", err)
+	}
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+	if desc.String() != expected {
+		t.Errorf("String() did not match Encode(): %s", desc.String())
+	}
+}
+
+// TestDescriptorEncodeNoConnectData checks that CONNECT_DATA is omitted when
+// none of its fields are set, and written for a bare COMMAND probe.
+func TestDescriptorEncodeNoConnectData(t *testing.T) {
+	desc := Descriptor{
+		Addresses:   []DescriptorAddress{{Protocol: "TCP", Host: "rac1", Port: 1521}},
+		LoadBalance: true,
+	}
+	expected := "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=rac1)(PORT=1521))(LOAD_BALANCE=ON))"
+	if actual, err := desc.Encode(); err != nil || actual != expected {
+		t.Errorf("Expected %s, got %s, %v", expected, actual, err)
+	}
+	desc = Descriptor{Command: "ping"}
+	expected = "(DESCRIPTION=(CONNECT_DATA=(COMMAND=ping)))"
+	if actual, err := desc.Encode(); err != nil || actual != expected {
+		t.Errorf("Expected %s, got %s, %v", expected, actual, err)
+	}
+	desc = Descriptor{CID: &DescriptorCID{}}
+	expected = "(DESCRIPTION=(CONNECT_DATA=(CID=)))"
+	if actual, err := desc.Encode(); err != nil || actual != expected {
+		t.Errorf("Expected %s, got %s, %v", expected, actual, err)
+	}
+}
+
+// TestDescriptorEncodeUnquotable checks that values containing both kinds of
+// quote are rejected rather than encoded into an unparseable descriptor,
+// while values containing only one kind survive a round trip.
+func TestDescriptorEncodeUnquotable(t *testing.T) {
+	for _, value := range []string{`a"b`, `it's`, `(x="1")`, `o'brien (x)`} {
+		desc := Descriptor{ServiceName: value, CID: &DescriptorCID{Program: value}}
+		encoded, err := desc.Encode()
+		if err != nil {
+			t.Errorf("Error encoding %s: %v", value, err)
+			continue
+		}
+		decoded, err := ParseDescriptor(encoded)
+		if err != nil {
+			t.Errorf("Error parsing %s: %v", encoded, err)
+			continue
+		}
+		if !reflect.DeepEqual(*decoded, desc) {
+			t.Errorf("Round trip mismatch for %s: expected %#v, got %#v", encoded, desc, *decoded)
+		}
+	}
+	for _, desc := range []Descriptor{
+		{ServiceName: `a"b'c`},
+		{CID: &DescriptorCID{User: `x')(PROGRAM="y`}},
+		{Addresses: []DescriptorAddress{{Host: `h"'`}}},
+	} {
+		if ret, err := desc.Encode(); err != ErrInvalidDescriptor {
+			t.Errorf("Expected ErrInvalidDescriptor for %#v, got %s, %v", desc, ret, err)
+		}
+		if ret := desc.String(); ret != "" {
+			t.Errorf("Expected empty String() for %#v, got %s", desc, ret)
+		}
+	}
+}
+
+// TestDescriptorStringStable checks that descriptors listing the same values
+// in different orders encode to identical bytes, and that repeated encodings
+// do not change.
+func TestDescriptorStringStable(t *testing.T) {
+	orderings := []string{
+		"(DESCRIPTION=(FAILOVER=ON)(ADDRESS=(PROTOCOL=TCP)(HOST=a)(PORT=1521))(ADDRESS=(PORT=1522)(HOST=b)(PROTOCOL=TCP))" +
+			"(CONNECT_DATA=(SERVER=DEDICATED)(SERVICE_NAME=orcl))(RETRY_COUNT=2))",
+		"(DESCRIPTION=(CONNECT_DATA=(SERVICE_NAME=orcl)(SERVER=DEDICATED))(RETRY_COUNT=2)" +
+			"(ADDRESS_LIST=(ADDRESS=(HOST=a)(PORT=1521)(PROTOCOL=TCP))(FAILOVER=ON)(ADDRESS=(PROTOCOL=TCP)(HOST=b)(PORT=1522))))",
+	}
+	expected := "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=a)(PORT=1521))(ADDRESS=(PROTOCOL=TCP)(HOST=b)(PORT=1522))" +
+		"(FAILOVER=ON)(RETRY_COUNT=2)(CONNECT_DATA=(SERVICE_NAME=orcl)(SERVER=DEDICATED)))"
+	for _, s := range orderings {
+		desc, err := ParseDescriptor(s)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %v", s, err)
+		}
+		for i := 0; i < 10; i++ {
+			if actual, err := desc.Encode(); err != nil || actual != expected {
+				t.Fatalf("Encoding %d of %s: expected %s, got %s, %v", i, s, expected, actual, err)
+			}
+		}
+	}
+}
+
+// TestDescriptorRoundTrip checks that parsing the output of Encode() gives
+// back the original descriptor.
+func TestDescriptorRoundTrip(t *testing.T) {
+	for s, expected := range descriptors {
+		encoded, err := expected.Encode()
+		if err != nil {
+			t.Errorf("Error encoding %s: %v", s, err)
+			continue
+		}
+		actual, err := ParseDescriptor(encoded)
+		if err != nil {
+			t.Errorf("Error parsing %s (encoded from %s): %v", encoded, s, err)
+			continue
+		}
+		if !reflect.DeepEqual(*actual, expected) {
+			t.Errorf("Round trip mismatch for %s: expected %#v, got %#v", encoded, expected, *actual)
+		}
+	}
+}
+
+// TestExpandDescriptorTemplate checks placeholder substitution and quoting.
+func TestExpandDescriptorTemplate(t *testing.T) {
+	template := "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST={target})(PORT={port}))" +
+		"(CONNECT_DATA=(SERVICE_NAME={service})(SID={sid})))"
+	values := DescriptorTemplateValues{
+		Target:  "2001:db8::1",
+		Port:    1521,
+		Service: "orcl)(SERVER=SHARED",
+	}
+	expected := "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=2001:db8::1)(PORT=1521))" +
+		`(CONNECT_DATA=(SERVICE_NAME="orcl)(SERVER=SHARED")(SID=)))`
+	actual, err := ExpandDescriptorTemplate(template, &values)
+	if err != nil {
+		t.Fatalf("Error expanding template: %v", err)
+	}
+	if actual != expected {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+	desc, err := ParseDescriptor(actual)
+	if err != nil {
+		t.Fatalf("Error parsing expanded template: %v", err)
+	}
+	if desc.ServiceName != values.Service || desc.Server != "" {
+		t.Errorf("Service name was not contained by quoting: %#v", desc)
+	}
+
+	values.Service = `a"b')(SERVER=SHARED)(X='`
+	if ret, err := ExpandDescriptorTemplate(template, &values); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor for unquotable value, got %s, %v", ret, err)
+	}
+	values.Service = "orcl"
+	if ret, err := ExpandDescriptorTemplate("(HOST={target}", &values); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor for malformed template, got %s, %v", ret, err)
+	}
+	if ret, err := ExpandDescriptorTemplate(template, nil); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor for nil values, got %s, %v", ret, err)
+	}
+	values.Port = 0
+	if ret, err := ExpandDescriptorTemplate(template, &values); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor for zero port, got %s, %v", ret, err)
+	}
+	if _, err := ExpandDescriptorTemplate("(CONNECT_DATA=(SERVICE_NAME={service}))", &values); err != nil {
+		t.Errorf("Zero port should be allowed when the template has no {port}: %v", err)
+	}
+}
+
+// TestCanonicalizeDescriptor checks that differently-formatted copies of the
+// same descriptor canonicalize to the same string.
+func TestCanonicalizeDescriptor(t *testing.T) {
+	expected := "(DESCRIPTION=(ADDRESS=(HOST=rac1)(PORT=1521)(PROTOCOL=TCP))(ADDRESS=(HOST=rac2)(PORT=1521)(PROTOCOL=TCP))" +
+		`(CONNECT_DATA=(SERVICE_NAME=Sales)(SSL_SERVER_CERT_DN="CN=db,O=x")))`
+	equivalent := []string{
+		"(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=rac1)(PORT=1521))(ADDRESS=(PROTOCOL=TCP)(HOST=rac2)(PORT=1521))" +
+			`(CONNECT_DATA=(SERVICE_NAME=Sales)(SSL_SERVER_CERT_DN="CN=db,O=x")))`,
+		`(description =
+		   (connect_data = (ssl_server_cert_dn = 'CN=db,O=x') (service_name = Sales))
+		   (address = (port = 1521) (host = rac1) (protocol = TCP))
+		   (Address = (Host = rac2) (Port = 1521) (Protocol = TCP))
+		 )`,
+	}
+	for _, s := range equivalent {
+		actual, err := CanonicalizeDescriptor(s)
+		if err != nil {
+			t.Errorf("Error canonicalizing %s: %v", s, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("Canonicalize(%s): expected %s, got %s", s, expected, actual)
+		}
+	}
+	if ret, err := CanonicalizeDescriptor("(DESCRIPTION="); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor, got %s, %v", ret, err)
+	}
+	if ret, err := CanonicalizeDescriptor(`(A=it's"x)`); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor for unquotable value, got %s, %v", ret, err)
+	}
+
+	// Addresses keep their failover order, whether or not they are in lists.
+	ordered := "(DESCRIPTION=(FAILOVER=on)(ADDRESS_LIST=(ADDRESS=(HOST=b)))(ADDRESS=(HOST=a))" +
+		"(ADDRESS_LIST=(LOAD_BALANCE=on)(ADDRESS=(HOST=d))(ADDRESS=(HOST=c))))"
+	expected = "(DESCRIPTION=(ADDRESS_LIST=(ADDRESS=(HOST=b)))(ADDRESS=(HOST=a))" +
+		"(ADDRESS_LIST=(ADDRESS=(HOST=d))(ADDRESS=(HOST=c))(LOAD_BALANCE=on))(FAILOVER=on))"
+	if actual, err := CanonicalizeDescriptor(ordered); err != nil || actual != expected {
+		t.Errorf("Canonicalize(%s): expected %s, got %s, %v", ordered, expected, actual, err)
+	}
+}
+
+// TestIsWebTransport checks which protocols are labeled as web transports.
+func TestIsWebTransport(t *testing.T) {
+	protocols := map[string]bool{
+		ProtocolTCP:   false,
+		ProtocolTCPS:  false,
+		ProtocolIPC:   false,
+		"":            false,
+		ProtocolWS:    true,
+		ProtocolWSS:   true,
+		ProtocolHTTPS: true,
+		"wss":         true,
+	}
+	for protocol, expected := range protocols {
+		addr := DescriptorAddress{Protocol: protocol}
+		if actual := addr.IsWebTransport(); actual != expected {
+			t.Errorf("IsWebTransport(%s): expected %v, got %v", protocol, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package oracle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DecodeVSNNUM converts a listener VSNNUM into the dotted five-part version
+// number, e.g. 186646784 (0x0B200100) becomes "11.2.0.1.0". The fields are
+// packed as 8 bits major, 4 bits maintenance, 8 bits application server,
+// 4 bits component and 8 bits platform.
+func DecodeVSNNUM(v uint32) string {
+	return fmt.Sprintf("%d.%d.%d.%d.%d", v>>24, (v>>20)&0x0f, (v>>12)&0xff, (v>>8)&0x0f, v&0xff)
+}
+
+// ListenerErrors maps TNS error numbers returned by the listener for
+// VERSION / SERVICES / STATUS commands to their meanings.
+var ListenerErrors = map[int]string{
+	1153:  "Failed to process string",
+	1169:  "The listener has not recognized the password",
+	1189:  "The listener could not authenticate the user",
+	1190:  "The user is not authorized to execute the requested listener command",
+	1194:  "The listener command did not arrive in a secure transport",
+	12508: "TNS:listener could not resolve the COMMAND given",
+}
+
+// ListenerError is returned when the listener answers a command with a
+// non-zero ERR, e.g. (DESCRIPTION=(ERR=1153)(VSNNUM=0)(ERROR_STACK=...)).
+type ListenerError struct {
+	// Code is the ERR value, a TNS error number (e.g. 1189 for TNS-01189).
+	Code int `json:"code"`
+}
+
+// Error returns the error as TNS-nnnnn, with its meaning from ListenerErrors
+// (or, for the 12xxx refusal codes, ORAErrors) if known.
+func (e *ListenerError) Error() string {
+	msg, ok := ListenerErrors[e.Code]
+	if !ok && e.Code >= 12000 && e.Code < 13000 {
+		msg, ok = ORAErrors[e.Code]
+	}
+	if ok {
+		return fmt.Sprintf("listener returned TNS-%05d: %s", e.Code, msg)
+	}
+	return fmt.Sprintf("listener returned TNS-%05d", e.Code)
+}
+
+// checkListenerErr returns a *ListenerError if pair has a non-zero ERR child.
+func checkListenerErr(pair *nvPair) error {
+	value := pair.childValue("ERR")
+	if value == "" {
+		return nil
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return ErrInvalidDescriptor
+	}
+	if code != 0 {
+		return &ListenerError{Code: code}
+	}
+	return nil
+}
+
+// ListenerRestriction reports whether err, as returned by
+// ParseListenerVersion or ParseListenerServices, shows that the listener
+// refuses remote commands (ADMIN_RESTRICTIONS, a listener password, local OS
+// authentication only, or a COMMAND it will not run remotely). code is the
+// ERR value of any *ListenerError, and 0 otherwise.
+func ListenerRestriction(err error) (restricted bool, code int) {
+	lerr, ok := err.(*ListenerError)
+	if !ok {
+		return false, 0
+	}
+	switch lerr.Code {
+	case 1169, 1189, 1190, 1194, 12508:
+		return true, lerr.Code
+	}
+	return false, lerr.Code
+}
+
+// ListenerVersion is the parsed response to a listener VERSION command.
+type ListenerVersion struct {
+	// VSNNUM is the raw VSNNUM value from the response descriptor.
+	VSNNUM uint32 `json:"vsnnum,omitempty"`
+
+	// Version is the dotted form of VSNNUM; it is empty if VSNNUM is 0.
+	Version string `json:"version,omitempty"`
+
+	// Banner is the free-text part of the response, e.g.
+	// "TNSLSNR for Linux: Version 11.2.0.2.0 - Production".
+	Banner string `json:"banner,omitempty"`
+}
+
+// indexFold returns the byte offset of the first ASCII case-insensitive match
+// of substr in s, or -1. Unlike searching strings.ToUpper(s), the offset is
+// always valid in s, even if s is not valid UTF-8.
+func indexFold(s string, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		match := true
+		for j := 0; j < len(substr); j++ {
+			a, b := s[i+j], substr[j]
+			if 'a' <= a && a <= 'z' {
+				a -= 'a' - 'A'
+			}
+			if 'a' <= b && b <= 'z' {
+				b -= 'a' - 'A'
+			}
+			if a != b {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseListenerVersion parses the data returned by the listener for a VERSION
+// command: a (DESCRIPTION=...(VSNNUM=n)...) descriptor, with the human-readable
+// banner before or after it. A *ListenerError is returned if the descriptor
+// has a non-zero ERR.
+func ParseListenerVersion(data string) (*ListenerVersion, error) {
+	idx := indexFold(data, "(DESCRIPTION")
+	if idx == -1 {
+		return nil, ErrInvalidDescriptor
+	}
+	p := &nvParser{input: data[idx:]}
+	pairs, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, ErrInvalidDescriptor
+	}
+	if err := checkListenerErr(pairs[0]); err != nil {
+		return nil, err
+	}
+	ret := ListenerVersion{
+		Banner: strings.TrimSpace(strings.TrimSpace(data[:idx]) + "\n" + strings.TrimSpace(data[idx+p.pos:])),
+	}
+	if vsnnum := pairs[0].childValue("VSNNUM"); vsnnum != "" {
+		v, err := strconv.ParseUint(vsnnum, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidDescriptor
+		}
+		ret.VSNNUM = uint32(v)
+	}
+	if ret.VSNNUM != 0 {
+		ret.Version = DecodeVSNNUM(ret.VSNNUM)
+	}
+	return &ret, nil
+}
+
+// ListenerService is one (service, instance, handler) entry from the response
+// to a listener SERVICES command.
+type ListenerService struct {
+	// ServiceName is the SERVICE_NAME the instance is registered under.
+	ServiceName string `json:"service_name,omitempty"`
+
+	// Instance is the INSTANCE_NAME.
+	Instance string `json:"instance,omitempty"`
+
+	// InstanceStatus is the INSTANCE_STATUS, e.g. "READY" or "BLOCKED".
+	InstanceStatus string `json:"instance_status,omitempty"`
+
+	// HandlerType is the handler's HANDLER_DISPLAY (e.g. "DEDICATED SERVER"
+	// or "DISPATCHER"), or its HANDLER_NAME if there is no display name.
+	HandlerType string `json:"handler_type,omitempty"`
+
+	// HandlerState is the handler's STA value, e.g. "ready" or "blocked".
+	HandlerState string `json:"handler_state,omitempty"`
+}
+
+// findPairs appends every descendant of pairs (including pairs themselves)
+// with the given name to ret; matching pairs are not searched further.
+func findPairs(ret []*nvPair, pairs []*nvPair, name string) []*nvPair {
+	for _, pair := range pairs {
+		if strings.EqualFold(pair.Name, name) {
+			ret = append(ret, pair)
+		} else {
+			ret = findPairs(ret, pair.Children, name)
+		}
+	}
+	return ret
+}
+
+// ParseListenerServices parses the registration data returned by the listener
+// for a SERVICES (or STATUS) command into one entry per handler. Instances
+// without any handlers are returned with empty handler fields. A
+// *ListenerError is returned if the listener refused the command, i.e. a
+// top-level DESCRIPTION has a non-zero ERR.
+func ParseListenerServices(data string) ([]ListenerService, error) {
+	idx := strings.IndexByte(data, '(')
+	if idx == -1 {
+		return nil, ErrInvalidDescriptor
+	}
+	p := &nvParser{input: data[idx:]}
+	pairs, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		if !strings.EqualFold(pair.Name, "DESCRIPTION") {
+			continue
+		}
+		if err := checkListenerErr(pair); err != nil {
+			return nil, err
+		}
+	}
+	var ret []ListenerService
+	for _, service := range findPairs(nil, pairs, "SERVICE") {
+		for _, instance := range findPairs(nil, service.Children, "INSTANCE") {
+			base := ListenerService{
+				ServiceName:    service.childValue("SERVICE_NAME"),
+				Instance:       instance.childValue("INSTANCE_NAME"),
+				InstanceStatus: instance.childValue("INSTANCE_STATUS"),
+			}
+			handlers := findPairs(nil, instance.Children, "HANDLER")
+			if len(handlers) == 0 {
+				ret = append(ret, base)
+			}
+			for _, handler := range handlers {
+				entry := base
+				entry.HandlerType = handler.childValue("HANDLER_DISPLAY")
+				if entry.HandlerType == "" {
+					entry.HandlerType = handler.childValue("HANDLER_NAME")
+				}
+				entry.HandlerState = handler.childValue("STA")
+				ret = append(ret, entry)
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,167 @@
+package oracle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// vsnnums maps VSNNUM values to their dotted versions.
+var vsnnums = map[uint32]string{
+	0x0B200200: "11.2.0.2.0",
+	0x0C100200: "12.1.0.2.0",
+	0x13000000: "19.0.0.0.0",
+	0x0A200100: "10.2.0.1.0",
+	0:          "0.0.0.0.0",
+}
+
+// TestDecodeVSNNUM checks the VSNNUM bit layout.
+func TestDecodeVSNNUM(t *testing.T) {
+	for v, expected := range vsnnums {
+		if actual := DecodeVSNNUM(v); actual != expected {
+			t.Errorf("DecodeVSNNUM(0x%08x): expected %s, got %s", v, expected, actual)
+		}
+	}
+}
+
+// listenerVersions maps VERSION command responses to their parsed form.
+var listenerVersions = map[string]ListenerVersion{
+	"(DESCRIPTION=(TMP=)(VSNNUM=186646784)(ERR=0)(ALIAS=LISTENER))": {
+		VSNNUM:  186646784,
+		Version: "11.2.0.1.0",
+	},
+	"(DESCRIPTION=(TMP=)(VSNNUM=318767104)(ERR=0))TNSLSNR for Linux: Version 19.0.0.0.0 - Production\n": {
+		VSNNUM:  318767104,
+		Version: "19.0.0.0.0",
+		Banner:  "TNSLSNR for Linux: Version 19.0.0.0.0 - Production",
+	},
+	"TNSLSNR for 32-bit Windows: Version 9.2.0.1.0 - Production (description=(err=0))": {
+		Banner: "TNSLSNR for 32-bit Windows: Version 9.2.0.1.0 - Production",
+	},
+	"(DESCRIPTION=(TMP=)(VSNNUM=0)(ERR=0))": {},
+}
+
+// TestParseListenerVersion checks VSNNUM and banner extraction.
+func TestParseListenerVersion(t *testing.T) {
+	for s, expected := range listenerVersions {
+		actual, err := ParseListenerVersion(s)
+		if err != nil {
+			t.Errorf("Error parsing %q: %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(*actual, expected) {
+			t.Errorf("Parse mismatch for %q: expected %#v, got %#v", s, expected, *actual)
+		}
+	}
+	invalid := []string{
+		"", "TNSLSNR", "(DESCRIPTION=(VSNNUM=abc))", "(DESCRIPTION=(VSNNUM=1)", "(DESCRIPTION=(ERR=x))",
+		"(DESCRIPTION)", "\xff(DESCRIPTION",
+	}
+	for _, s := range invalid {
+		if ret, err := ParseListenerVersion(s); err != ErrInvalidDescriptor {
+			t.Errorf("Expected ErrInvalidDescriptor for %q, got %#v, %v", s, ret, err)
+		}
+	}
+	// Invalid UTF-8 before the descriptor must not shift its offset.
+	garbled := map[string]ListenerVersion{
+		strings.Repeat("\xff", 20) + "(DESCRIPTION=(VSNNUM=1))": {
+			VSNNUM: 1, Version: "0.0.0.0.1", Banner: strings.Repeat("\xff", 20),
+		},
+		"\xff(DESCRIPTION=(VSNNUM=318767104))": {
+			VSNNUM: 318767104, Version: "19.0.0.0.0", Banner: "\xff",
+		},
+	}
+	for s, expected := range garbled {
+		actual, err := ParseListenerVersion(s)
+		if err != nil {
+			t.Errorf("Error parsing %q: %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(*actual, expected) {
+			t.Errorf("Parse mismatch for %q: expected %#v, got %#v", s, expected, *actual)
+		}
+	}
+	refused := "(DESCRIPTION=(ERR=1153)(VSNNUM=0)(ERROR_STACK=(ERROR=(CODE=1153)(EMFI=4)" +
+		"(ARGS='(ADDRESS=(PROTOCOL=tcp)(HOST=0.0.0.0)(PORT=1521))'))))"
+	ret, err := ParseListenerVersion(refused)
+	if lerr, ok := err.(*ListenerError); !ok || lerr.Code != 1153 {
+		t.Errorf("Expected ListenerError 1153 for %q, got %#v, %v", refused, ret, err)
+	}
+}
+
+// TestListenerError checks that listener codes are reported as TNS errors and
+// not annotated with unrelated database errors.
+func TestListenerError(t *testing.T) {
+	messages := map[int]string{
+		1153:  "listener returned TNS-01153: Failed to process string",
+		1189:  "listener returned TNS-01189: The listener could not authenticate the user",
+		12514: "listener returned TNS-12514: " + ORAErrors[12514],
+		1017:  "listener returned TNS-01017",
+		99999: "listener returned TNS-99999",
+	}
+	for code, expected := range messages {
+		if actual := (&ListenerError{Code: code}).Error(); actual != expected {
+			t.Errorf("ListenerError{%d}: expected %q, got %q", code, expected, actual)
+		}
+	}
+}
+
+// TestListenerRestriction checks which command errors mark the listener as
+// restricted.
+func TestListenerRestriction(t *testing.T) {
+	type restriction struct {
+		restricted bool
+		code       int
+	}
+	errs := map[string]restriction{
+		"(DESCRIPTION=(TMP=)(VSNNUM=318767104)(ERR=1189)(ERROR_STACK=(ERROR=(CODE=1189)(EMFI=4))))": {true, 1189},
+		"(DESCRIPTION=(ERR=1169))":                                        {true, 1169},
+		"(DESCRIPTION=(ERR=12508))":                                       {true, 12508},
+		"(DESCRIPTION=(ERR=1153))":                                        {false, 1153},
+		"(DESCRIPTION=(TMP=)(VSNNUM=318767104)(ERR=0)(SERVICES_EXIST=0))": {false, 0},
+		"(DESCRIPTION=":                                                   {false, 0},
+	}
+	for data, expected := range errs {
+		_, err := ParseListenerServices(data)
+		restricted, code := ListenerRestriction(err)
+		if (restriction{restricted, code}) != expected {
+			t.Errorf("ListenerRestriction(%v) for %q: expected %v, got %v, %d", err, data, expected, restricted, code)
+		}
+	}
+}
+
+// TestParseListenerServices checks that each handler of each instance is
+// returned.
+func TestParseListenerServices(t *testing.T) {
+	data := "(DESCRIPTION=(TMP=)(VSNNUM=318767104)(ERR=0)(SERVICES_EXIST=1))" +
+		"(SERVICE=(SERVICE_NAME=orcl)(INSTANCE=(INSTANCE_NAME=orcl1)(NUM=1)(INSTANCE_STATUS=READY)" +
+		"(HANDLER=(HANDLER_DISPLAY=DEDICATED SERVER)(STA=ready)(HANDLER_INFO=LOCAL SERVER)(HANDLER_NAME=DEDICATED))" +
+		"(HANDLER=(HANDLER_NAME=D000)(STA=blocked)(ADDRESS=(PROTOCOL=tcp)(HOST=db)(PORT=41233))))" +
+		"(INSTANCE=(INSTANCE_NAME=orcl2)(INSTANCE_STATUS=UNKNOWN))(NUMREL=1))" +
+		"(SERVICE=(SERVICE_NAME=orclXDB)(INSTANCE=(INSTANCE_NAME=orcl1)(INSTANCE_STATUS=READY)" +
+		"(HANDLER=(HANDLER_DISPLAY=DISPATCHER <machine: db, pid: 1234>)(STA=ready))))"
+	expected := []ListenerService{
+		{ServiceName: "orcl", Instance: "orcl1", InstanceStatus: "READY", HandlerType: "DEDICATED SERVER", HandlerState: "ready"},
+		{ServiceName: "orcl", Instance: "orcl1", InstanceStatus: "READY", HandlerType: "D000", HandlerState: "blocked"},
+		{ServiceName: "orcl", Instance: "orcl2", InstanceStatus: "UNKNOWN"},
+		{ServiceName: "orclXDB", Instance: "orcl1", InstanceStatus: "READY", HandlerType: "DISPATCHER <machine: db, pid: 1234>", HandlerState: "ready"},
+	}
+	actual, err := ParseListenerServices(data)
+	if err != nil {
+		t.Fatalf("Error parsing services: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, actual)
+	}
+	if ret, err := ParseListenerServices("(DESCRIPTION=(ERR=0)(SERVICES_EXIST=0))"); err != nil || len(ret) != 0 {
+		t.Errorf("Expected no services, got %#v, %v", ret, err)
+	}
+	refused := "(DESCRIPTION=(TMP=)(VSNNUM=318767104)(ERR=12508)(ERROR_STACK=(ERROR=(CODE=12508)(EMFI=4))))"
+	ret, err := ParseListenerServices(refused)
+	if lerr, ok := err.(*ListenerError); !ok || lerr.Code != 12508 || ret != nil {
+		t.Errorf("Expected ListenerError 12508 for %q, got %#v, %v", refused, ret, err)
+	}
+	if ret, err := ParseListenerServices("(SERVICE=(SERVICE_NAME=x)"); err != ErrInvalidDescriptor {
+		t.Errorf("Expected ErrInvalidDescriptor, got %#v, %v", ret, err)
+	}
+}
@@ -0,0 +1,121 @@
+package oracle
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ORAErrors maps ORA error numbers commonly seen in listener Refuse payloads
+// to their meanings.
+var ORAErrors = map[int]string{
+	1017:  "invalid username/password; logon denied",
+	12154: "TNS:could not resolve the connect identifier specified",
+	12170: "TNS:Connect timeout occurred",
+	12500: "TNS:listener failed to start a dedicated server process",
+	12502: "TNS:listener received no CONNECT_DATA from client",
+	12504: "TNS:listener was not given the SID in CONNECT_DATA",
+	12505: "TNS:listener does not currently know of SID given in connect descriptor",
+	12508: "TNS:listener could not resolve the COMMAND given",
+	12511: "TNS:service handler found but it is not accepting connections",
+	12514: "TNS:listener does not currently know of service requested in connect descriptor",
+	12516: "TNS:listener could not find available handler with matching protocol stack",
+	12518: "TNS:listener could not hand off client connection",
+	12519: "TNS:no appropriate service handler found",
+	12520: "TNS:listener could not find available handler for requested type of server",
+	12521: "TNS:listener does not currently know of instance requested in connect descriptor",
+	12523: "TNS:listener could not find instance appropriate for the client connection",
+	12526: "TNS:listener: all appropriate instances are in restricted mode",
+	12527: "TNS:listener: all instances are in restricted mode or blocking new connections",
+	12528: "TNS:listener: all appropriate instances are blocking new connections",
+	12529: "TNS:connect request rejected based on current filtering rules",
+	12537: "TNS:connection closed",
+	12541: "TNS:no listener",
+	12545: "Connect failed because target host or object does not exist",
+	12546: "TNS:permission denied",
+	12547: "TNS:lost contact",
+	12560: "TNS:protocol adapter error",
+	12564: "TNS:connection refused",
+	12599: "TNS:cryptographic checksum mismatch",
+	12630: "Native service operation not supported",
+	12650: "No common encryption or data integrity algorithm",
+	12660: "Encryption or crypto-checksumming parameters incompatible",
+	28040: "No matching authentication protocol",
+}
+
+// ORAError is an error number found in a Refuse payload, along with its
+// meaning from ORAErrors (empty if the number is not in the table).
+type ORAError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	// oraErrorLiteral matches error numbers written as ORA-nnnnn.
+	oraErrorLiteral = regexp.MustCompile(`(?i)\bORA-(\d+)\b`)
+
+	// oraErrorPair matches error numbers in the (ERR=n) / (CODE=n) pairs of a
+	// Refuse descriptor, e.g. (ERR=12514)(ERROR_STACK=(ERROR=(CODE=12514)...)).
+	oraErrorPair = regexp.MustCompile(`(?i)\((?:ERR|CODE)\s*=\s*(\d+)\s*\)`)
+)
+
+// FindORAErrors extracts the non-zero ORA error numbers from a Refuse payload,
+// in the order they first appear and without duplicates, and annotates each
+// with its meaning.
+func FindORAErrors(payload []byte) []ORAError {
+	type match struct {
+		pos  int
+		code int
+	}
+	var matches []match
+	for _, re := range []*regexp.Regexp{oraErrorLiteral, oraErrorPair} {
+		for _, loc := range re.FindAllSubmatchIndex(payload, -1) {
+			code, err := strconv.Atoi(string(payload[loc[2]:loc[3]]))
+			if err != nil || code == 0 {
+				continue
+			}
+			matches = append(matches, match{pos: loc[0], code: code})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].pos < matches[j].pos
+	})
+	var ret []ORAError
+	seen := make(map[int]bool)
+	for _, m := range matches {
+		if seen[m.code] {
+			continue
+		}
+		seen[m.code] = true
+		ret = append(ret, ORAError{Code: m.code, Message: ORAErrors[m.code]})
+	}
+	return ret
+}
+
+// hasORAError returns true if FindORAErrors finds any of codes in payload.
+func hasORAError(payload []byte, codes ...int) bool {
+	for _, found := range FindORAErrors(payload) {
+		for _, code := range codes {
+			if found.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsEncryptionRequiredRefusal returns true if a Refuse payload indicates that
+// the server requires native network encryption or crypto-checksumming
+// (SQLNET.ENCRYPTION_SERVER=REQUIRED or similar) that the client did not
+// offer, i.e. it carries ORA-12650, ORA-12660 or ORA-12599.
+func IsEncryptionRequiredRefusal(payload []byte) bool {
+	return hasORAError(payload, 12650, 12660, 12599)
+}
+
+// IsValidNodeCheckingRefusal returns true if a Refuse payload looks like a
+// TCP.VALIDNODE_CHECKING rejection, i.e. the listener is reachable but does
+// not accept connections from this address: it carries ORA-12546 (permission
+// denied) or ORA-12537 (connection closed).
+func IsValidNodeCheckingRefusal(payload []byte) bool {
+	return hasORAError(payload, 12546, 12537)
+}
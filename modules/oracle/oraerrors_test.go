@@ -0,0 +1,70 @@
+package oracle
+
+import (
+	"reflect"
+	"testing"
+)
+
+// refusePayloads maps Refuse payloads to the errors expected to be found in
+// them.
+var refusePayloads = map[string][]ORAError{
+	"(DESCRIPTION=(TMP=)(VSNNUM=318767104)(ERR=12514)(ERROR_STACK=(ERROR=(CODE=12514)(EMFI=4))))": {
+		{Code: 12514, Message: ORAErrors[12514]},
+	},
+	"(DESCRIPTION=(ERR=12505)(ERROR_STACK=(ERROR=(CODE=12505)(EMFI=4))(ERROR=(CODE=99999)(EMFI=1))))": {
+		{Code: 12505, Message: ORAErrors[12505]},
+		{Code: 99999},
+	},
+	"ORA-28040: No matching authentication protocol (err=0)": {
+		{Code: 28040, Message: "No matching authentication protocol"},
+	},
+	"(DESCRIPTION=(TMP=)(VSNNUM=0)(ERR=0))": nil,
+	"":                                      nil,
+}
+
+// TestFindORAErrors checks extraction, ordering, de-duplication and
+// annotation of ORA error numbers.
+func TestFindORAErrors(t *testing.T) {
+	for payload, expected := range refusePayloads {
+		actual := FindORAErrors([]byte(payload))
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("FindORAErrors(%q): expected %#v, got %#v", payload, expected, actual)
+		}
+	}
+}
+
+// TestIsEncryptionRequiredRefusal checks which refusals indicate that the
+// server requires encryption.
+func TestIsEncryptionRequiredRefusal(t *testing.T) {
+	payloads := map[string]bool{
+		"(DESCRIPTION=(ERR=12650)(ERROR_STACK=(ERROR=(CODE=12650)(EMFI=4))))": true,
+		"(DESCRIPTION=(ERR=12660)(VSNNUM=318767104))":                         true,
+		"ORA-12599: TNS:cryptographic checksum mismatch":                      true,
+		"(DESCRIPTION=(ERR=12514)(ERROR_STACK=(ERROR=(CODE=12514)(EMFI=4))))": false,
+		"(DESCRIPTION=(ERR=0))":                                               false,
+		"":                                                                    false,
+	}
+	for payload, expected := range payloads {
+		if actual := IsEncryptionRequiredRefusal([]byte(payload)); actual != expected {
+			t.Errorf("IsEncryptionRequiredRefusal(%q): expected %v, got %v", payload, expected, actual)
+		}
+	}
+}
+
+// TestIsValidNodeCheckingRefusal checks which refusals indicate an
+// IP-restricted listener.
+func TestIsValidNodeCheckingRefusal(t *testing.T) {
+	payloads := map[string]bool{
+		"(DESCRIPTION=(ERR=12546)(ERROR_STACK=(ERROR=(CODE=12546)(EMFI=4))))": true,
+		"(DESCRIPTION=(TMP=)(VSNNUM=0)(ERR=12537))":                           true,
+		"ORA-12546: TNS:permission denied":                                    true,
+		"(DESCRIPTION=(ERR=12505)(ERROR_STACK=(ERROR=(CODE=12505)(EMFI=4))))": false,
+		"(DESCRIPTION=(ERR=12650))":                                           false,
+		"":                                                                    false,
+	}
+	for payload, expected := range payloads {
+		if actual := IsValidNodeCheckingRefusal([]byte(payload)); actual != expected {
+			t.Errorf("IsValidNodeCheckingRefusal(%q): expected %v, got %v", payload, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package oracle
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	// ErrInvalidAcceptedVersions is returned when an AcceptedVersions blob is
+	// not NUL-terminated.
+	ErrInvalidAcceptedVersions = errors.New("AcceptedVersions is not NUL-terminated")
+)
+
+// EncodeAcceptedVersions builds the AcceptedVersions field of a SetProtocol
+// message: the supported protocol version bytes, in order of preference,
+// followed by a NUL. Since the NUL terminates the list, any 0 bytes in
+// versions are dropped.
+func EncodeAcceptedVersions(versions []byte) []byte {
+	ret := make([]byte, 0, len(versions)+1)
+	for _, v := range versions {
+		if v != 0 {
+			ret = append(ret, v)
+		}
+	}
+	return append(ret, 0)
+}
+
+// DecodeAcceptedVersions returns the protocol version bytes from an
+// AcceptedVersions blob, i.e. everything before the first NUL. Anything after
+// the NUL (such as the platform string) is ignored.
+func DecodeAcceptedVersions(blob []byte) ([]byte, error) {
+	idx := bytes.IndexByte(blob, 0)
+	if idx == -1 {
+		return nil, ErrInvalidAcceptedVersions
+	}
+	return append([]byte{}, blob[:idx]...), nil
+}
@@ -0,0 +1,57 @@
+package oracle
+
+import (
+	"bytes"
+	"testing"
+)
+
+// acceptedVersions maps version lists to their encoded form.
+var acceptedVersions = map[string][]byte{
+	"\x06\x05\x04\x03\x02\x01": {6, 5, 4, 3, 2, 1, 0},
+	"\x06":                     {6, 0},
+	"":                         {0},
+}
+
+// TestAcceptedVersionsRoundTrip checks that encoding appends the trailing NUL
+// and that decoding gives back the original list.
+func TestAcceptedVersionsRoundTrip(t *testing.T) {
+	for versions, expected := range acceptedVersions {
+		encoded := EncodeAcceptedVersions([]byte(versions))
+		if !bytes.Equal(encoded, expected) {
+			t.Errorf("EncodeAcceptedVersions(%x): expected %x, got %x", versions, expected, encoded)
+		}
+		decoded, err := DecodeAcceptedVersions(encoded)
+		if err != nil {
+			t.Errorf("Error decoding %x: %v", encoded, err)
+			continue
+		}
+		if string(decoded) != versions {
+			t.Errorf("DecodeAcceptedVersions(%x): expected %x, got %x", encoded, versions, decoded)
+		}
+	}
+}
+
+// TestEncodeAcceptedVersionsZero checks that 0 bytes, which would terminate
+// the list early, are dropped.
+func TestEncodeAcceptedVersionsZero(t *testing.T) {
+	encoded := EncodeAcceptedVersions([]byte{6, 0, 5})
+	if expected := []byte{6, 5, 0}; !bytes.Equal(encoded, expected) {
+		t.Errorf("Expected %x, got %x", expected, encoded)
+	}
+	if decoded, err := DecodeAcceptedVersions(encoded); err != nil || !bytes.Equal(decoded, []byte{6, 5}) {
+		t.Errorf("Expected 0605, got %x, %v", decoded, err)
+	}
+}
+
+// TestDecodeAcceptedVersions checks trailing data and missing terminators.
+func TestDecodeAcceptedVersions(t *testing.T) {
+	blob := append([]byte{6, 5, 0}, "Linux\x00"...)
+	if actual, err := DecodeAcceptedVersions(blob); err != nil || !bytes.Equal(actual, []byte{6, 5}) {
+		t.Errorf("Expected 0605, got %x, %v", actual, err)
+	}
+	for _, blob := range [][]byte{nil, {}, {6, 5}} {
+		if ret, err := DecodeAcceptedVersions(blob); err != ErrInvalidAcceptedVersions {
+			t.Errorf("Expected ErrInvalidAcceptedVersions for %x, got %x, %v", blob, ret, err)
+		}
+	}
+}
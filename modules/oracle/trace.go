@@ -0,0 +1,17 @@
+package oracle
+
+import (
+	"crypto/sha256"
+)
+
+// NewConnectionID derives the pair of 8-byte trace IDs carried in the
+// ConnectionID0 / ConnectionID1 fields of a Connect from seed (e.g. a scan ID
+// concatenated with the target). The same seed always gives the same IDs, so
+// a listener trace file can be matched against the scan that produced it.
+func NewConnectionID(seed []byte) ([8]byte, [8]byte) {
+	var id0, id1 [8]byte
+	sum := sha256.Sum256(seed)
+	copy(id0[:], sum[0:8])
+	copy(id1[:], sum[8:16])
+	return id0, id1
+}
@@ -0,0 +1,28 @@
+package oracle
+
+import (
+	"testing"
+)
+
+// TestNewConnectionID checks that IDs are deterministic and depend on the
+// seed.
+func TestNewConnectionID(t *testing.T) {
+	a0, a1 := NewConnectionID([]byte("scan-1|10.0.0.1:1521"))
+	b0, b1 := NewConnectionID([]byte("scan-1|10.0.0.1:1521"))
+	if a0 != b0 || a1 != b1 {
+		t.Errorf("Same seed gave different IDs: %x/%x vs %x/%x", a0, a1, b0, b1)
+	}
+	if a0 == a1 {
+		t.Errorf("ConnectionID0 and ConnectionID1 are identical: %x", a0)
+	}
+	c0, c1 := NewConnectionID([]byte("scan-1|10.0.0.2:1521"))
+	if a0 == c0 || a1 == c1 {
+		t.Errorf("Different seeds gave overlapping IDs: %x/%x vs %x/%x", a0, a1, c0, c1)
+	}
+	// sha256("") = e3b0c442 98fc1c14 9afbf4c8 996fb924 ...
+	e0, e1 := NewConnectionID(nil)
+	if e0 != [8]byte{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14} ||
+		e1 != [8]byte{0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24} {
+		t.Errorf("Unexpected IDs for empty seed: %x/%x", e0, e1)
+	}
+}